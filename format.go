@@ -0,0 +1,127 @@
+package netpbm
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+func init() {
+	image.RegisterFormat("pbm", "P1", decodePBM, decodeConfigPBM)
+	image.RegisterFormat("pbm", "P4", decodePBM, decodeConfigPBM)
+	image.RegisterFormat("pgm", "P2", decodePGM, decodeConfigPGM)
+	image.RegisterFormat("pgm", "P5", decodePGM, decodeConfigPGM)
+	image.RegisterFormat("ppm", "P3", decodePPM, decodeConfigPPM)
+	image.RegisterFormat("ppm", "P6", decodePPM, decodeConfigPPM)
+}
+
+func decodePBM(r io.Reader) (image.Image, error) {
+	return ReadPBM(r)
+}
+
+func decodePGM(r io.Reader) (image.Image, error) {
+	return ReadPGM(r)
+}
+
+func decodePPM(r io.Reader) (image.Image, error) {
+	return ReadPPM(r)
+}
+
+// decodeConfigPBM reads just enough of the header to report the image's
+// dimensions, without decoding the raster.
+func decodeConfigPBM(r io.Reader) (image.Config, error) {
+	hs := newHeaderScanner(r)
+
+	magicNumber, err := hs.token()
+	if err != nil {
+		return image.Config{}, err
+	}
+	if magicNumber != "P1" && magicNumber != "P4" {
+		return image.Config{}, errors.New("Invalid PBM magic number")
+	}
+
+	width, height, err := readDimensions(hs)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.GrayModel, Width: width, Height: height}, nil
+}
+
+func decodeConfigPGM(r io.Reader) (image.Config, error) {
+	hs := newHeaderScanner(r)
+
+	magicNumber, err := hs.token()
+	if err != nil {
+		return image.Config{}, err
+	}
+	if magicNumber != "P2" && magicNumber != "P5" {
+		return image.Config{}, errors.New("Unsupported PGM format")
+	}
+
+	width, height, err := readDimensions(hs)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	maxValStr, err := hs.token()
+	if err != nil {
+		return image.Config{}, errors.New("Invalid max value")
+	}
+	maxVal, err := strconv.Atoi(maxValStr)
+	if err != nil {
+		return image.Config{}, errors.New("Invalid max value")
+	}
+
+	model := color.Model(color.GrayModel)
+	if maxVal > 255 {
+		model = color.Gray16Model
+	}
+
+	return image.Config{ColorModel: model, Width: width, Height: height}, nil
+}
+
+func decodeConfigPPM(r io.Reader) (image.Config, error) {
+	hs := newHeaderScanner(r)
+
+	magicNumber, err := hs.token()
+	if err != nil {
+		return image.Config{}, err
+	}
+	if magicNumber != "P3" && magicNumber != "P6" {
+		return image.Config{}, errors.New("Unsupported PPM format")
+	}
+
+	width, height, err := readDimensions(hs)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.RGBAModel, Width: width, Height: height}, nil
+}
+
+// readDimensions reads the width and height tokens that follow every
+// Netpbm magic number.
+func readDimensions(hs *headerScanner) (width, height int, err error) {
+	widthStr, err := hs.token()
+	if err != nil {
+		return 0, 0, errors.New("Invalid width")
+	}
+	width, err = strconv.Atoi(widthStr)
+	if err != nil {
+		return 0, 0, errors.New("Invalid width")
+	}
+
+	heightStr, err := hs.token()
+	if err != nil {
+		return 0, 0, errors.New("Invalid height")
+	}
+	height, err = strconv.Atoi(heightStr)
+	if err != nil {
+		return 0, 0, errors.New("Invalid height")
+	}
+
+	return width, height, nil
+}
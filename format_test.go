@@ -0,0 +1,163 @@
+package netpbm
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestReadPBMBinaryCommentsAndWhitespace exercises P4 header parsing with
+// comments and irregular whitespace between tokens, and checks the packed
+// bitmap raster that follows decodes to the expected bits.
+func TestReadPBMBinaryCommentsAndWhitespace(t *testing.T) {
+	// width=10 packs to 2 bytes/row: 0b11110000 0b11000000 is bits
+	// 1111000011 (10 bits, the last 6 of the second byte are padding).
+	src := []byte("P4 # comment\n\t10  3\n" +
+		"\xF0\xC0\xF0\xC0\xF0\xC0")
+
+	pbm, err := ReadPBM(bytes.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadPBM: %v", err)
+	}
+
+	w, h := pbm.Size()
+	if w != 10 || h != 3 {
+		t.Fatalf("Size() = %d,%d, want 10,3", w, h)
+	}
+
+	want := []bool{true, true, true, true, false, false, false, false, true, true}
+	for y := 0; y < h; y++ {
+		for x, wantBit := range want {
+			if got := pbm.BitAt(x, y); got != wantBit {
+				t.Errorf("BitAt(%d,%d) = %v, want %v", x, y, got, wantBit)
+			}
+		}
+	}
+}
+
+// TestReadPBMBinaryRoundTrip writes a P4 PBM via Save and reads it back,
+// checking every bit survives the round trip. Regression test for Save
+// emitting one raw byte per pixel plus a row delimiter instead of packing
+// 8 pixels/byte with no delimiters, the way ReadPBM expects.
+func TestReadPBMBinaryRoundTrip(t *testing.T) {
+	want := [][]bool{
+		{true, true, true, true, false, false, false, false, true, true},
+		{false, true, false, true, false, true, false, true, false, true},
+		{true, false, true, false, true, false, true, false, true, false},
+	}
+	pbm := &PBM{
+		data:        want,
+		width:       10,
+		height:      3,
+		magicNumber: "P4",
+	}
+
+	var buf bytes.Buffer
+	if err := pbm.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	header := "P4\n10 3\n"
+	wantBytes := len(header) + 2*3 // header + (width+7)/8 * height
+	if buf.Len() != wantBytes {
+		t.Fatalf("Save wrote %d bytes, want %d", buf.Len(), wantBytes)
+	}
+
+	got, err := ReadPBM(&buf)
+	if err != nil {
+		t.Fatalf("ReadPBM: %v", err)
+	}
+
+	for y := range want {
+		for x := range want[y] {
+			if b := got.BitAt(x, y); b != want[y][x] {
+				t.Errorf("BitAt(%d,%d) = %v, want %v", x, y, b, want[y][x])
+			}
+		}
+	}
+}
+
+// TestReadPGM16BitBigEndianRoundTrip writes a P5 PGM with MAXVAL above 255
+// (16-bit, big-endian samples) via Save and reads it back, checking every
+// sample survives the round trip.
+func TestReadPGM16BitBigEndianRoundTrip(t *testing.T) {
+	want := [][]uint16{
+		{0, 300, 65535},
+		{1, 32768, 4096},
+	}
+	pgm := &PGM{
+		data:        want,
+		width:       3,
+		height:      2,
+		magicNumber: "P5",
+		max:         65535,
+	}
+
+	var buf bytes.Buffer
+	if err := pgm.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := ReadPGM(&buf)
+	if err != nil {
+		t.Fatalf("ReadPGM: %v", err)
+	}
+
+	for y := range want {
+		for x := range want[y] {
+			if v := got.GrayAt(x, y); v != want[y][x] {
+				t.Errorf("GrayAt(%d,%d) = %d, want %d", x, y, v, want[y][x])
+			}
+		}
+	}
+}
+
+// TestReadPGMBinaryComment checks that a "#" comment embedded in a P5
+// header is skipped rather than being mistaken for a header token.
+func TestReadPGMBinaryComment(t *testing.T) {
+	src := append([]byte("P5\n# this is a comment\n2 1\n255\n"), 0x10, 0xFF)
+
+	pgm, err := ReadPGM(bytes.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadPGM: %v", err)
+	}
+	if v := pgm.GrayAt(0, 0); v != 0x10 {
+		t.Errorf("GrayAt(0,0) = %d, want 16", v)
+	}
+	if v := pgm.GrayAt(1, 0); v != 0xFF {
+		t.Errorf("GrayAt(1,0) = %d, want 255", v)
+	}
+}
+
+// TestReadPPMBinaryRoundTrip writes a P6 PPM via Save and reads it back,
+// checking every pixel survives the round trip.
+func TestReadPPMBinaryRoundTrip(t *testing.T) {
+	want := [][]Pixel{
+		{{R: 1, G: 2, B: 3}, {R: 250, G: 251, B: 252}},
+		{{R: 0, G: 0, B: 0}, {R: 255, G: 255, B: 255}},
+	}
+	ppm := &PPM{
+		data:        want,
+		width:       2,
+		height:      2,
+		magicNumber: "P6",
+		max:         255,
+	}
+
+	var buf bytes.Buffer
+	if err := ppm.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := ReadPPM(&buf)
+	if err != nil {
+		t.Fatalf("ReadPPM: %v", err)
+	}
+
+	for y := range want {
+		for x := range want[y] {
+			if p := got.PixelAt(x, y); p != want[y][x] {
+				t.Errorf("PixelAt(%d,%d) = %+v, want %+v", x, y, p, want[y][x])
+			}
+		}
+	}
+}
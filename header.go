@@ -0,0 +1,62 @@
+package netpbm
+
+import (
+	"bufio"
+	"io"
+)
+
+// headerScanner tokenizes a Netpbm header (magic number, width, height,
+// maxval), skipping "#...\n" comments and arbitrary whitespace between
+// tokens, as required by the Netpbm spec. The raster that follows the
+// header can then be read directly off the underlying *bufio.Reader.
+type headerScanner struct {
+	r *bufio.Reader
+}
+
+// newHeaderScanner wraps r in a headerScanner, reusing r if it is already
+// a *bufio.Reader so no bytes are lost between header and raster reads.
+func newHeaderScanner(r io.Reader) *headerScanner {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &headerScanner{r: br}
+}
+
+// token reads the next whitespace-delimited header token, skipping any
+// "#" comments that run to the end of their line.
+func (hs *headerScanner) token() (string, error) {
+	var buf []byte
+	for {
+		b, err := hs.r.ReadByte()
+		if err != nil {
+			if len(buf) > 0 {
+				return string(buf), nil
+			}
+			return "", err
+		}
+
+		if b == '#' {
+			for {
+				c, err := hs.r.ReadByte()
+				if err != nil || c == '\n' {
+					break
+				}
+			}
+			continue
+		}
+
+		if isNetpbmSpace(b) {
+			if len(buf) > 0 {
+				return string(buf), nil
+			}
+			continue
+		}
+
+		buf = append(buf, b)
+	}
+}
+
+func isNetpbmSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,322 @@
+package netpbm
+
+import "math"
+
+// ResampleFilter selects the interpolation kernel used by Resize.
+type ResampleFilter int
+
+const (
+	NearestNeighbor ResampleFilter = iota
+	Bilinear
+	Bicubic
+	Lanczos3
+)
+
+// kernel returns the filter's support radius and its weighting function.
+func (f ResampleFilter) kernel() (support float64, weight func(float64) float64) {
+	switch f {
+	case NearestNeighbor:
+		return 0.5, nearestNeighborKernel
+	case Bicubic:
+		return 2, bicubicKernel
+	case Lanczos3:
+		return 3, lanczos3Kernel
+	default:
+		return 1, bilinearKernel
+	}
+}
+
+func nearestNeighborKernel(x float64) float64 {
+	if x >= -0.5 && x < 0.5 {
+		return 1
+	}
+	return 0
+}
+
+func bilinearKernel(x float64) float64 {
+	x = math.Abs(x)
+	if x < 1 {
+		return 1 - x
+	}
+	return 0
+}
+
+// bicubicKernel is the Catmull-Rom cubic convolution kernel (a = -0.5).
+func bicubicKernel(x float64) float64 {
+	const a = -0.5
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return (a+2)*x*x*x - (a+3)*x*x + 1
+	case x < 2:
+		return a*x*x*x - 5*a*x*x + 8*a*x - 4*a
+	default:
+		return 0
+	}
+}
+
+func lanczos3Kernel(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	if x <= -3 || x >= 3 {
+		return 0
+	}
+	px := math.Pi * x
+	return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+}
+
+// transpose swaps the rows and columns of a plane.
+func transpose(src [][]float64) [][]float64 {
+	h := len(src)
+	if h == 0 {
+		return nil
+	}
+	w := len(src[0])
+	dst := make([][]float64, w)
+	for x := 0; x < w; x++ {
+		dst[x] = make([]float64, h)
+		for y := 0; y < h; y++ {
+			dst[x][y] = src[y][x]
+		}
+	}
+	return dst
+}
+
+// resample1D resamples every row of src to dstLen samples using a
+// separable 1D convolution with the given filter kernel.
+func resample1D(src [][]float64, dstLen int, support float64, weight func(float64) float64) [][]float64 {
+	if dstLen < 0 {
+		dstLen = 0
+	}
+	if len(src) == 0 {
+		return nil
+	}
+	srcLen := len(src[0])
+	if srcLen == 0 || dstLen == 0 {
+		dst := make([][]float64, len(src))
+		for y := range dst {
+			dst[y] = make([]float64, dstLen)
+		}
+		return dst
+	}
+
+	scale := float64(dstLen) / float64(srcLen)
+	filterScale := support
+	if scale < 1 {
+		filterScale = support / scale
+	}
+
+	dst := make([][]float64, len(src))
+	for y := range src {
+		dst[y] = make([]float64, dstLen)
+	}
+
+	for dx := 0; dx < dstLen; dx++ {
+		center := (float64(dx)+0.5)/scale - 0.5
+		left := int(math.Floor(center - filterScale))
+		right := int(math.Ceil(center + filterScale))
+
+		interp := scale
+		if interp > 1 {
+			interp = 1
+		}
+
+		var indices []int
+		var weights []float64
+		sum := 0.0
+		for sx := left; sx <= right; sx++ {
+			w := weight((float64(sx) - center) * interp)
+			if w == 0 {
+				continue
+			}
+			clamped := sx
+			if clamped < 0 {
+				clamped = 0
+			} else if clamped >= srcLen {
+				clamped = srcLen - 1
+			}
+			indices = append(indices, clamped)
+			weights = append(weights, w)
+			sum += w
+		}
+		if sum == 0 {
+			sum = 1
+		}
+
+		for y := range src {
+			acc := 0.0
+			for k, idx := range indices {
+				acc += src[y][idx] * weights[k]
+			}
+			dst[y][dx] = acc / sum
+		}
+	}
+
+	return dst
+}
+
+// resizePlane resizes a single-channel plane to dstW x dstH using separable
+// 1D convolutions, horizontally then vertically.
+func resizePlane(src [][]float64, dstW, dstH int, filter ResampleFilter) [][]float64 {
+	support, weight := filter.kernel()
+	horizontal := resample1D(src, dstW, support, weight)
+	vertical := transpose(resample1D(transpose(horizontal), dstH, support, weight))
+	return vertical
+}
+
+// gaussianKernel1D builds a normalized 1D Gaussian kernel sized
+// ceil(3*sigma)*2+1, as used by the two-pass GaussianBlur.
+func gaussianKernel1D(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	if radius < 1 {
+		radius = 1
+	}
+	kernel := make([]float64, radius*2+1)
+	sum := 0.0
+	for i := range kernel {
+		x := float64(i - radius)
+		v := math.Exp(-(x * x) / (2 * sigma * sigma))
+		kernel[i] = v
+		sum += v
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// blurPlane applies a two-pass separable Gaussian blur to a plane.
+func blurPlane(src [][]float64, sigma float64) [][]float64 {
+	if len(src) == 0 {
+		return nil
+	}
+
+	kernel := gaussianKernel1D(sigma)
+	radius := len(kernel) / 2
+	h := len(src)
+	w := len(src[0])
+
+	horizontal := make([][]float64, h)
+	for y := 0; y < h; y++ {
+		horizontal[y] = make([]float64, w)
+		for x := 0; x < w; x++ {
+			acc := 0.0
+			for k := -radius; k <= radius; k++ {
+				acc += src[y][clampInt(x+k, 0, w-1)] * kernel[k+radius]
+			}
+			horizontal[y][x] = acc
+		}
+	}
+
+	out := make([][]float64, h)
+	for y := 0; y < h; y++ {
+		out[y] = make([]float64, w)
+	}
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			acc := 0.0
+			for k := -radius; k <= radius; k++ {
+				acc += horizontal[clampInt(y+k, 0, h-1)][x] * kernel[k+radius]
+			}
+			out[y][x] = acc
+		}
+	}
+
+	return out
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// shiftRow1D resamples row by a fractional shift using linear
+// interpolation, filling positions that fall outside the row with fill.
+func shiftRow1D(row []float64, shift, fill float64) []float64 {
+	n := len(row)
+	out := make([]float64, n)
+	for x := 0; x < n; x++ {
+		srcPos := float64(x) - shift
+		lo := int(math.Floor(srcPos))
+		frac := srcPos - float64(lo)
+		out[x] = sampleOr(row, lo, fill)*(1-frac) + sampleOr(row, lo+1, fill)*frac
+	}
+	return out
+}
+
+func sampleOr(row []float64, idx int, fill float64) float64 {
+	if idx < 0 || idx >= len(row) {
+		return fill
+	}
+	return row[idx]
+}
+
+// rotatePlaneThreeShear rotates a plane by theta radians using the
+// classic three-shear decomposition (shear-X, shear-Y, shear-X), which
+// avoids the blurring a single affine resample would introduce. The
+// returned plane is sized to the bounding box of the rotated rectangle,
+// with uncovered pixels set to fill.
+func rotatePlaneThreeShear(src [][]float64, theta, fill float64) [][]float64 {
+	if len(src) == 0 {
+		return nil
+	}
+
+	h := len(src)
+	w := len(src[0])
+
+	sinT, cosT := math.Sin(theta), math.Cos(theta)
+	newW := int(math.Ceil(math.Abs(float64(w)*cosT) + math.Abs(float64(h)*sinT)))
+	newH := int(math.Ceil(math.Abs(float64(w)*sinT) + math.Abs(float64(h)*cosT)))
+	if newW < 1 {
+		newW = 1
+	}
+	if newH < 1 {
+		newH = 1
+	}
+
+	canvas := make([][]float64, newH)
+	offX := (newW - w) / 2
+	offY := (newH - h) / 2
+	for y := 0; y < newH; y++ {
+		canvas[y] = make([]float64, newW)
+		for x := 0; x < newW; x++ {
+			canvas[y][x] = fill
+		}
+	}
+	for y := 0; y < h; y++ {
+		copy(canvas[y+offY][offX:offX+w], src[y])
+	}
+
+	cx, cy := float64(newW)/2, float64(newH)/2
+	shearX := -math.Tan(theta / 2)
+	shearY := sinT
+
+	canvas = shearRows(canvas, shearX, cy, fill)
+	canvas = shearCols(canvas, shearY, cx, fill)
+	canvas = shearRows(canvas, shearX, cy, fill)
+
+	return canvas
+}
+
+func shearRows(src [][]float64, factor, center, fill float64) [][]float64 {
+	dst := make([][]float64, len(src))
+	for y, row := range src {
+		dst[y] = shiftRow1D(row, factor*(float64(y)-center), fill)
+	}
+	return dst
+}
+
+func shearCols(src [][]float64, factor, center, fill float64) [][]float64 {
+	t := transpose(src)
+	dst := make([][]float64, len(t))
+	for x, col := range t {
+		dst[x] = shiftRow1D(col, factor*(float64(x)-center), fill)
+	}
+	return transpose(dst)
+}
@@ -0,0 +1,159 @@
+package netpbm
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+)
+
+func readTestPGM(t *testing.T, path string) *PGM {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	pgm, err := ReadPGM(f)
+	if err != nil {
+		t.Fatalf("ReadPGM(%s): %v", path, err)
+	}
+	return pgm
+}
+
+func readTestPPM(t *testing.T, path string) *PPM {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	ppm, err := ReadPPM(f)
+	if err != nil {
+		t.Fatalf("ReadPPM(%s): %v", path, err)
+	}
+	return ppm
+}
+
+// TestPGMCropReference crops the reference gradient PGM and checks the
+// result against the known sub-grid of the source values.
+func TestPGMCropReference(t *testing.T) {
+	pgm := readTestPGM(t, "testdata/gradient.pgm")
+	pgm.Crop(image.Rect(1, 1, 3, 3))
+
+	w, h := pgm.Size()
+	if w != 2 || h != 2 {
+		t.Fatalf("Size() = %d,%d, want 2,2", w, h)
+	}
+	want := [][]uint16{{85, 170}, {85, 170}}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if got := pgm.GrayAt(x, y); got != want[y][x] {
+				t.Errorf("GrayAt(%d,%d) = %d, want %d", x, y, got, want[y][x])
+			}
+		}
+	}
+}
+
+// TestPGMResizeNearestIdentity resizes the reference gradient PGM to its
+// own dimensions using NearestNeighbor, which should reproduce every
+// sample exactly.
+func TestPGMResizeNearestIdentity(t *testing.T) {
+	pgm := readTestPGM(t, "testdata/gradient.pgm")
+	w, h := pgm.Size()
+
+	want := make([][]uint16, h)
+	for y := 0; y < h; y++ {
+		want[y] = make([]uint16, w)
+		for x := 0; x < w; x++ {
+			want[y][x] = pgm.GrayAt(x, y)
+		}
+	}
+
+	pgm.Resize(w, h, NearestNeighbor)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if got := pgm.GrayAt(x, y); got != want[y][x] {
+				t.Errorf("GrayAt(%d,%d) = %d, want %d", x, y, got, want[y][x])
+			}
+		}
+	}
+}
+
+// TestPGMRotateArbitraryZeroIsIdentity checks that rotating by 0 degrees
+// leaves the reference gradient PGM unchanged.
+func TestPGMRotateArbitraryZeroIsIdentity(t *testing.T) {
+	pgm := readTestPGM(t, "testdata/gradient.pgm")
+	w, h := pgm.Size()
+
+	want := make([][]uint16, h)
+	for y := 0; y < h; y++ {
+		want[y] = make([]uint16, w)
+		for x := 0; x < w; x++ {
+			want[y][x] = pgm.GrayAt(x, y)
+		}
+	}
+
+	pgm.RotateArbitrary(0, color.Black)
+
+	gotW, gotH := pgm.Size()
+	if gotW != w || gotH != h {
+		t.Fatalf("Size() = %d,%d, want %d,%d", gotW, gotH, w, h)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if got := pgm.GrayAt(x, y); got != want[y][x] {
+				t.Errorf("GrayAt(%d,%d) = %d, want %d", x, y, got, want[y][x])
+			}
+		}
+	}
+}
+
+// TestPPMGaussianBlurUniformReference blurs the reference solid-color PPM,
+// which must be unchanged: convolving a constant signal with a normalized
+// kernel reproduces the same constant.
+func TestPPMGaussianBlurUniformReference(t *testing.T) {
+	ppm := readTestPPM(t, "testdata/solid.ppm")
+	want := ppm.PixelAt(0, 0)
+
+	ppm.GaussianBlur(1.5)
+
+	w, h := ppm.Size()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if got := ppm.PixelAt(x, y); got != want {
+				t.Errorf("PixelAt(%d,%d) = %+v, want %+v", x, y, got, want)
+			}
+		}
+	}
+}
+
+// TestPGMResizeZeroDimension is a regression test: resizing to a
+// zero width or height used to panic in resample1D/transpose instead of
+// producing an empty image.
+func TestPGMResizeZeroDimension(t *testing.T) {
+	pgm := readTestPGM(t, "testdata/gradient.pgm")
+
+	pgm.Resize(0, 3, Bilinear)
+	if w, h := pgm.Size(); w != 0 || h != 0 {
+		t.Fatalf("Size() after zero-width resize = %d,%d, want 0,0", w, h)
+	}
+
+	// Resizing back up from an empty image must also not panic, even
+	// though there is nothing meaningful to resample.
+	pgm.Resize(4, 4, Bilinear)
+	if w, h := pgm.Size(); w != 0 || h != 0 {
+		t.Fatalf("Size() after resizing an empty image = %d,%d, want 0,0", w, h)
+	}
+}
+
+// TestPPMResizeZeroDimension mirrors TestPGMResizeZeroDimension for PPM.
+func TestPPMResizeZeroDimension(t *testing.T) {
+	ppm := readTestPPM(t, "testdata/solid.ppm")
+
+	ppm.Resize(5, 0, Bilinear)
+	if w, h := ppm.Size(); w != 0 || h != 0 {
+		t.Fatalf("Size() after zero-height resize = %d,%d, want 0,0", w, h)
+	}
+}
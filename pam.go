@@ -0,0 +1,276 @@
+package netpbm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// PAM represents a Netpbm arbitrary-depth image (P7): a tagged-header
+// container that can hold any DEPTH/MAXVAL/TUPLTYPE combination, including
+// alpha. Samples are stored interleaved, row-major, depth per pixel.
+type PAM struct {
+	data          [][]uint16 // data[y] has width*depth samples, channel-interleaved
+	width, height int
+	depth         int
+	max           int
+	tupleType     string
+}
+
+// ReadPAM reads a PAM image from r and returns a struct that represents the image.
+func ReadPAM(r io.Reader) (*PAM, error) {
+	hs := newHeaderScanner(r)
+
+	magicNumber, err := hs.token()
+	if err != nil {
+		return nil, err
+	}
+	if magicNumber != "P7" {
+		return nil, errors.New("Invalid PAM magic number")
+	}
+
+	var width, height, depth, maxVal int
+	var tupleType string
+
+	for {
+		key, err := hs.token()
+		if err != nil {
+			return nil, err
+		}
+		if key == "ENDHDR" {
+			break
+		}
+
+		value, err := hs.token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "WIDTH":
+			width, err = strconv.Atoi(value)
+		case "HEIGHT":
+			height, err = strconv.Atoi(value)
+		case "DEPTH":
+			depth, err = strconv.Atoi(value)
+		case "MAXVAL":
+			maxVal, err = strconv.Atoi(value)
+		case "TUPLTYPE":
+			tupleType = value
+		default:
+			return nil, fmt.Errorf("Unknown PAM header field %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for %s", key)
+		}
+	}
+
+	if width <= 0 || height <= 0 || depth <= 0 || maxVal <= 0 {
+		return nil, errors.New("Incomplete PAM header")
+	}
+
+	data := make([][]uint16, height)
+	rowLen := width * depth
+
+	if maxVal > 255 {
+		raster := make([]byte, rowLen*height*2)
+		if _, err := io.ReadFull(hs.r, raster); err != nil {
+			return nil, err
+		}
+		for y := range data {
+			data[y] = make([]uint16, rowLen)
+			for i := 0; i < rowLen; i++ {
+				offset := (y*rowLen + i) * 2
+				data[y][i] = binary.BigEndian.Uint16(raster[offset : offset+2])
+			}
+		}
+	} else {
+		raster := make([]byte, rowLen*height)
+		if _, err := io.ReadFull(hs.r, raster); err != nil {
+			return nil, err
+		}
+		for y := range data {
+			data[y] = make([]uint16, rowLen)
+			for i := 0; i < rowLen; i++ {
+				data[y][i] = uint16(raster[y*rowLen+i])
+			}
+		}
+	}
+
+	return &PAM{
+		data:      data,
+		width:     width,
+		height:    height,
+		depth:     depth,
+		max:       maxVal,
+		tupleType: tupleType,
+	}, nil
+}
+
+// Size returns the width and height of the image.
+func (pam *PAM) Size() (int, int) {
+	return pam.width, pam.height
+}
+
+// Depth returns the number of channels per pixel.
+func (pam *PAM) Depth() int {
+	return pam.depth
+}
+
+// TupleAt returns the raw channel samples of the pixel at (x, y).
+func (pam *PAM) TupleAt(x, y int) []uint16 {
+	offset := x * pam.depth
+	return pam.data[y][offset : offset+pam.depth]
+}
+
+// Save writes the PAM image to w and returns an error if there was a problem.
+func (pam *PAM) Save(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "P7\nWIDTH %d\nHEIGHT %d\nDEPTH %d\nMAXVAL %d\nTUPLTYPE %s\nENDHDR\n",
+		pam.width, pam.height, pam.depth, pam.max, pam.tupleType)
+
+	if pam.max > 255 {
+		sample := make([]byte, 2)
+		for _, row := range pam.data {
+			for _, v := range row {
+				binary.BigEndian.PutUint16(sample, v)
+				writer.Write(sample)
+			}
+		}
+	} else {
+		for _, row := range pam.data {
+			for _, v := range row {
+				writer.WriteByte(byte(v))
+			}
+		}
+	}
+
+	return writer.Flush()
+}
+
+// ToPPM converts the PAM image to PPM, taking the first three channels of
+// each tuple as R, G and B and discarding any further channels (such as an
+// alpha channel in RGB_ALPHA).
+func (pam *PAM) ToPPM() *PPM {
+	ppmData := make([][]Pixel, pam.height)
+	for y := 0; y < pam.height; y++ {
+		ppmData[y] = make([]Pixel, pam.width)
+		for x := 0; x < pam.width; x++ {
+			tuple := pam.TupleAt(x, y)
+			ppmData[y][x] = Pixel{
+				R: pam.to8Bit(tuple[0]),
+				G: pam.to8Bit(tuple[channelOrFirst(pam.depth, 1)]),
+				B: pam.to8Bit(tuple[channelOrFirst(pam.depth, 2)]),
+			}
+		}
+	}
+
+	return &PPM{
+		data:        ppmData,
+		width:       pam.width,
+		height:      pam.height,
+		magicNumber: "P6",
+		max:         255,
+	}
+}
+
+// channelOrFirst returns idx if the tuple has a channel at that index
+// (idx < depth), or 0 otherwise, so grayscale/black-and-white PAMs can reuse their single
+// channel for every RGB component.
+func channelOrFirst(depth, idx int) int {
+	if idx < depth {
+		return idx
+	}
+	return 0
+}
+
+// ToPGM converts the PAM image to a grayscale PGM image, using the first
+// channel directly for GRAYSCALE/BLACKANDWHITE tuples and the standard
+// luminance weighting for RGB/RGB_ALPHA tuples.
+func (pam *PAM) ToPGM() *PGM {
+	// RGB/RGB_ALPHA tuples are downscaled to 8 bits before luminance is
+	// computed, so the resulting PGM's max must be 255 to match, the same
+	// way ToPPM downscales and declares max: 255. GRAYSCALE/BLACKANDWHITE
+	// tuples are copied through unscaled and keep pam.max.
+	max := pam.max
+	if pam.depth >= 3 {
+		max = 255
+	}
+
+	pgmData := make([][]uint16, pam.height)
+	for y := 0; y < pam.height; y++ {
+		pgmData[y] = make([]uint16, pam.width)
+		for x := 0; x < pam.width; x++ {
+			tuple := pam.TupleAt(x, y)
+			if pam.depth < 3 {
+				pgmData[y][x] = tuple[0]
+			} else {
+				r, g, b := pam.to8Bit(tuple[0]), pam.to8Bit(tuple[1]), pam.to8Bit(tuple[2])
+				pgmData[y][x] = uint16(Pixel{r, g, b}.luminance())
+			}
+		}
+	}
+
+	return &PGM{
+		data:        pgmData,
+		width:       pam.width,
+		height:      pam.height,
+		magicNumber: "P5",
+		max:         max,
+	}
+}
+
+// ToPBM converts the PAM image to a black-and-white PBM image, thresholding
+// on the grayscale conversion.
+func (pam *PAM) ToPBM() *PBM {
+	return pam.ToPGM().ToPBM()
+}
+
+// to8Bit scales a sample from this image's max value down to the 0-255
+// range, which is a no-op for already-8-bit PAMs.
+func (pam *PAM) to8Bit(sample uint16) uint8 {
+	if pam.max <= 255 {
+		return uint8(sample)
+	}
+	return uint8(uint32(sample) * 255 / uint32(pam.max))
+}
+
+// ToPAM converts the PPM image to PAM. If alpha is non-nil (one byte per
+// pixel, row-major) the result is RGB_ALPHA with depth 4; otherwise it is
+// plain RGB with depth 3.
+func (ppm *PPM) ToPAM(alpha []byte) *PAM {
+	depth := 3
+	tupleType := "RGB"
+	if alpha != nil {
+		depth = 4
+		tupleType = "RGB_ALPHA"
+	}
+
+	data := make([][]uint16, ppm.height)
+	for y := 0; y < ppm.height; y++ {
+		row := make([]uint16, ppm.width*depth)
+		for x := 0; x < ppm.width; x++ {
+			pixel := ppm.data[y][x]
+			offset := x * depth
+			row[offset] = uint16(pixel.R)
+			row[offset+1] = uint16(pixel.G)
+			row[offset+2] = uint16(pixel.B)
+			if alpha != nil {
+				row[offset+3] = uint16(alpha[y*ppm.width+x])
+			}
+		}
+		data[y] = row
+	}
+
+	return &PAM{
+		data:      data,
+		width:     ppm.width,
+		height:    ppm.height,
+		depth:     depth,
+		max:       255,
+		tupleType: tupleType,
+	}
+}
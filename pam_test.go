@@ -0,0 +1,80 @@
+package netpbm
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestPAMRoundTrip writes a 16-bit RGB_ALPHA PAM via Save and reads it
+// back, checking the header fields and every tuple survive the round trip.
+func TestPAMRoundTrip(t *testing.T) {
+	pam := &PAM{
+		data: [][]uint16{
+			{0, 0, 0, 0, 65535, 65535, 65535, 65535},
+			{256, 512, 768, 1024, 2000, 3000, 4000, 5000},
+		},
+		width:     2,
+		height:    2,
+		depth:     4,
+		max:       65535,
+		tupleType: "RGB_ALPHA",
+	}
+
+	var buf bytes.Buffer
+	if err := pam.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := ReadPAM(&buf)
+	if err != nil {
+		t.Fatalf("ReadPAM: %v", err)
+	}
+
+	if w, h := got.Size(); w != 2 || h != 2 {
+		t.Fatalf("Size() = %d,%d, want 2,2", w, h)
+	}
+	if got.Depth() != 4 {
+		t.Fatalf("Depth() = %d, want 4", got.Depth())
+	}
+
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			gotTuple := got.TupleAt(x, y)
+			wantTuple := pam.TupleAt(x, y)
+			for c := range wantTuple {
+				if gotTuple[c] != wantTuple[c] {
+					t.Errorf("TupleAt(%d,%d)[%d] = %d, want %d", x, y, c, gotTuple[c], wantTuple[c])
+				}
+			}
+		}
+	}
+}
+
+// TestPAMToPGMScalesToOwnMaxForWideTuples is a regression test: converting
+// an RGB/RGB_ALPHA PAM to PGM must scale luminance to the returned PGM's
+// own max, not leave it at the PAM's (possibly 16-bit) max unscaled.
+func TestPAMToPGMScalesToOwnMaxForWideTuples(t *testing.T) {
+	pam := &PAM{
+		data: [][]uint16{
+			{65535, 65535, 65535},
+		},
+		width:     1,
+		height:    1,
+		depth:     3,
+		max:       65535,
+		tupleType: "RGB",
+	}
+
+	pgm := pam.ToPGM()
+	if pgm.max != 255 {
+		t.Fatalf("ToPGM().max = %d, want 255", pgm.max)
+	}
+	if v := pgm.GrayAt(0, 0); v != uint16(pgm.max) {
+		t.Errorf("GrayAt(0,0) = %d, want %d (white at its own max)", v, pgm.max)
+	}
+
+	// A fully white pixel must threshold to an ON bit, not OFF.
+	if bit := pam.ToPBM().BitAt(0, 0); !bit {
+		t.Error("ToPBM().BitAt(0,0) = false, want true for a fully white pixel")
+	}
+}
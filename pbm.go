@@ -1,65 +1,82 @@
-package main
+package netpbm
 
 import (
 	"bufio"
 	"errors"
 	"fmt"
-	"os"
+	"image"
+	"image/color"
+	"io"
 	"strconv"
-	"strings"
 )
 
+// PBM represents a Netpbm bitmap (P1/P4) image.
 type PBM struct {
 	data          [][]bool
 	width, height int
 	magicNumber   string
 }
 
-// ReadPBM reads a PBM image from a file and returns a struct that represents the image.
-func ReadPBM(filename string) (*PBM, error) {
-	file, err := os.Open(filename)
+// ReadPBM reads a PBM image from r and returns a struct that represents the image.
+func ReadPBM(r io.Reader) (*PBM, error) {
+	hs := newHeaderScanner(r)
+
+	magicNumber, err := hs.token()
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-
-	// Read the magic number
-	scanner.Scan()
-	magicNumber := scanner.Text()
-
-	// Check if it is a valid PBM magic number
 	if magicNumber != "P1" && magicNumber != "P4" {
 		return nil, errors.New("Invalid PBM magic number")
 	}
 
-	// Read width and height
-	scanner.Scan()
-	dimensions := strings.Fields(scanner.Text())
-	if len(dimensions) != 2 {
-		return nil, errors.New("Invalid dimensions")
+	widthStr, err := hs.token()
+	if err != nil {
+		return nil, errors.New("Invalid width")
 	}
-
-	width, err := strconv.Atoi(dimensions[0])
+	width, err := strconv.Atoi(widthStr)
 	if err != nil {
 		return nil, errors.New("Invalid width")
 	}
 
-	height, err := strconv.Atoi(dimensions[1])
+	heightStr, err := hs.token()
+	if err != nil {
+		return nil, errors.New("Invalid height")
+	}
+	height, err := strconv.Atoi(heightStr)
 	if err != nil {
 		return nil, errors.New("Invalid height")
 	}
 
-	// Read image data
 	data := make([][]bool, height)
-	for i := 0; i < height; i++ {
-		scanner.Scan()
-		line := scanner.Text()
-		if magicNumber == "P1" {
-			data[i] = parseP1Line(line, width)
-		} else {
-			data[i] = parseP4Line(line, width)
+
+	if magicNumber == "P1" {
+		for i := range data {
+			data[i] = make([]bool, width)
+			for j := range data[i] {
+				bit, err := hs.token()
+				if err != nil {
+					return nil, err
+				}
+				data[i][j] = bit == "1"
+			}
+		}
+	} else {
+		// P4: the raster is a contiguous byte stream, each row padded to a
+		// byte boundary, with no delimiters between rows.
+		rowBytes := (width + 7) / 8
+		raster := make([]byte, rowBytes*height)
+		if _, err := io.ReadFull(hs.r, raster); err != nil {
+			return nil, err
+		}
+
+		for i := range data {
+			data[i] = make([]bool, width)
+			row := raster[i*rowBytes : (i+1)*rowBytes]
+			for j := 0; j < width; j++ {
+				byteIndex := j / 8
+				bitPos := uint(7 - (j % 8))
+				data[i][j] = (row[byteIndex]>>bitPos)&1 == 1
+			}
 		}
 	}
 
@@ -71,108 +88,93 @@ func ReadPBM(filename string) (*PBM, error) {
 	}, nil
 }
 
-// Helper function to parse P1 (ASCII) line
-func parseP1Line(line string, width int) []bool {
-	data := make([]bool, width)
-	for i, char := range line {
-		data[i] = char == '1'
-	}
-	return data
-}
-
-// Helper function to parse P4 (binary) line
-func parseP4Line(line string, width int) []bool {
-	data := make([]bool, width)
-
-	// Ensure that the line has enough bytes to cover the width
-	if len(line) < (width+7)/8 {
-		return nil
-	}
-
-	for i := 0; i < width; i++ {
-		// Calculate the byte index and bit position within the byte
-		byteIndex := i / 8
-		bitPos := uint(7 - (i % 8))
-
-		// Extract the bit from the byte
-		bit := (line[byteIndex] >> bitPos) & 1
-		data[i] = bit == 1
-	}
-
-	return data
-}
-
 // Size returns the width and height of the image.
 func (pbm *PBM) Size() (int, int) {
 	return pbm.width, pbm.height
 }
 
-// At returns the value of the pixel at (x, y).
-func (pbm *PBM) At(x, y int) bool {
+// BitAt returns the raw bit value of the pixel at (x, y).
+func (pbm *PBM) BitAt(x, y int) bool {
 	return pbm.data[y][x]
 }
 
-// Set sets the value of the pixel at (x, y).
-func (pbm *PBM) Set(x, y int, value bool) {
+// SetBit sets the raw bit value of the pixel at (x, y).
+func (pbm *PBM) SetBit(x, y int, value bool) {
 	pbm.data[y][x] = value
 }
 
-// Save saves the PBM image to a file and returns an error if there was a problem.
-func (pbm *PBM) Save(filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+// ColorModel returns the Color Model of the image, satisfying image.Image.
+func (pbm *PBM) ColorModel() color.Model {
+	return color.GrayModel
+}
+
+// Bounds returns the domain for which At can return non-zero color,
+// satisfying image.Image.
+func (pbm *PBM) Bounds() image.Rectangle {
+	return image.Rect(0, 0, pbm.width, pbm.height)
+}
+
+// At returns the color of the pixel at (x, y), satisfying image.Image.
+func (pbm *PBM) At(x, y int) color.Color {
+	if pbm.data[y][x] {
+		return color.Gray{Y: 255}
 	}
-	defer file.Close()
+	return color.Gray{Y: 0}
+}
 
-	// Write magic number, width, and height
-	_, err = fmt.Fprintf(file, "%s\n%d %d\n", pbm.magicNumber, pbm.width, pbm.height)
-	if err != nil {
+// Save writes the PBM image to w and returns an error if there was a problem.
+func (pbm *PBM) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := fmt.Fprintf(bw, "%s\n%d %d\n", pbm.magicNumber, pbm.width, pbm.height); err != nil {
 		return err
 	}
 
-	// Write image data
-	for _, row := range pbm.data {
-		for _, pixel := range row {
-			if pbm.magicNumber == "P1" {
+	if pbm.magicNumber == "P1" {
+		for _, row := range pbm.data {
+			for _, pixel := range row {
 				if pixel {
-					_, err = file.WriteString("1 ")
+					bw.WriteString("1 ")
 				} else {
-					_, err = file.WriteString("0 ")
+					bw.WriteString("0 ")
 				}
-			} else {
-				// For P4 format, write binary data
+			}
+			bw.WriteString("\n")
+		}
+	} else {
+		// P4: pack 8 pixels/byte, row-padded to a byte boundary, with no
+		// delimiters between rows, mirroring the unpacking in ReadPBM.
+		rowBytes := (pbm.width + 7) / 8
+		packed := make([]byte, rowBytes)
+		for _, row := range pbm.data {
+			for i := range packed {
+				packed[i] = 0
+			}
+			for j, pixel := range row {
 				if pixel {
-					_, err = file.Write([]byte{0xFF})
-				} else {
-					_, err = file.Write([]byte{0x00})
+					packed[j/8] |= 1 << uint(7-(j%8))
 				}
 			}
-		}
-		_, err = file.WriteString("\n")
-		if err != nil {
-			return err
+			if _, err := bw.Write(packed); err != nil {
+				return err
+			}
 		}
 	}
 
-	return nil
+	return bw.Flush()
 }
 
 // Invert inverts the colors of the PBM image.
 func (pbm *PBM) Invert() {
-	for y := 0; y < pbm.height; y++ {
-		for x := 0; x < pbm.width; x++ {
-			pbm.data[y][x] = !pbm.data[y][x]
-		}
+	for _, row := range pbm.data {
+		invertBitRow(row)
 	}
 }
 
 // Flip flips the PBM image horizontally.
 func (pbm *PBM) Flip() {
-	for y := 0; y < pbm.height; y++ {
-		for x := 0; x < pbm.width/2; x++ {
-			pbm.data[y][x], pbm.data[y][pbm.width-x-1] = pbm.data[y][pbm.width-x-1], pbm.data[y][x]
-		}
+	for _, row := range pbm.data {
+		flipBitRow(row)
 	}
 }
 
@@ -188,34 +190,45 @@ func (pbm *PBM) SetMagicNumber(magicNumber string) {
 	pbm.magicNumber = magicNumber
 }
 
-func main() {
-	filename := "C:/Users/JENGO/Netbpm/sample_640426.pbm"
-	pbm, err := ReadPBM(filename)
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+// ToPGM converts the PBM image to PGM, mapping black/white to 0/max.
+func (pbm *PBM) ToPGM() *PGM {
+	pgmData := make([][]uint16, pbm.height)
+	for i := 0; i < pbm.height; i++ {
+		pgmData[i] = make([]uint16, pbm.width)
+		for j := 0; j < pbm.width; j++ {
+			if pbm.data[i][j] {
+				pgmData[i][j] = 255
+			}
+		}
 	}
 
-	fmt.Printf("Magic Number: %s\n", pbm.magicNumber)
-	fmt.Printf("Width: %d\n", pbm.width)
-	fmt.Printf("Height: %d\n", pbm.height)
-
-	width, height := pbm.Size()
-	fmt.Printf("Image Size: %d x %d\n", width, height)
-
-	x, y := 2, 3
-	fmt.Printf("Pixel at (%d, %d): %v\n", x, y, pbm.At(x, y))
-
-	newValue := true
-	pbm.Set(x, y, newValue)
-	fmt.Printf("New pixel value at (%d, %d): %v\n", x, y, pbm.At(x, y))
+	return &PGM{
+		data:        pgmData,
+		width:       pbm.width,
+		height:      pbm.height,
+		magicNumber: "P2",
+		max:         255,
+	}
+}
 
-	outputFilename := "output.pbm"
-	err = pbm.Save(outputFilename)
-	if err != nil {
-		fmt.Println("Error saving the PBM image:", err)
-		return
+// ToPPM converts the PBM image to PPM, mapping black/white to pure
+// black/white pixels.
+func (pbm *PBM) ToPPM() *PPM {
+	ppmData := make([][]Pixel, pbm.height)
+	for i := 0; i < pbm.height; i++ {
+		ppmData[i] = make([]Pixel, pbm.width)
+		for j := 0; j < pbm.width; j++ {
+			if pbm.data[i][j] {
+				ppmData[i][j] = Pixel{255, 255, 255}
+			}
+		}
 	}
 
-	fmt.Println("PBM image saved successfully to", outputFilename)
-}
\ No newline at end of file
+	return &PPM{
+		data:        ppmData,
+		width:       pbm.width,
+		height:      pbm.height,
+		magicNumber: "P3",
+		max:         255,
+	}
+}
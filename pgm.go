@@ -1,53 +1,107 @@
-package main
+package netpbm
 
 import (
 	"bufio"
+	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
+	"image"
+	"image/color"
+	"io"
+	"math"
 	"strconv"
-	"strings"
 )
 
-// PGM struct to represent a PGM image
+// PGM struct to represent a PGM image. Samples are stored as uint16 so
+// that both the common 8-bit (maxval <= 255) and 16-bit (maxval > 255,
+// big-endian on disk) Netpbm grayscale formats can be represented.
 type PGM struct {
-	data        [][]uint8
+	data        [][]uint16
 	width       int
 	height      int
 	magicNumber string
 	max         int
 }
 
-// ReadPGM reads a PGM image from a file and returns a struct that represents the image.
-func ReadPGM(filename string) (*PGM, error) {
-	file, err := os.Open(filename)
+// ReadPGM reads a PGM image from r and returns a struct that represents the image.
+func ReadPGM(r io.Reader) (*PGM, error) {
+	hs := newHeaderScanner(r)
+
+	magicNumber, err := hs.token()
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Scan()
-	magicNumber := scanner.Text()
 	if magicNumber != "P2" && magicNumber != "P5" {
 		return nil, errors.New("Unsupported PGM format")
 	}
 
-	scanner.Scan()
-	dimensions := strings.Fields(scanner.Text())
-	width, _ := strconv.Atoi(dimensions[0])
-	height, _ := strconv.Atoi(dimensions[1])
+	widthStr, err := hs.token()
+	if err != nil {
+		return nil, errors.New("Invalid width")
+	}
+	width, err := strconv.Atoi(widthStr)
+	if err != nil {
+		return nil, errors.New("Invalid width")
+	}
 
-	scanner.Scan()
-	maxVal, _ := strconv.Atoi(scanner.Text())
+	heightStr, err := hs.token()
+	if err != nil {
+		return nil, errors.New("Invalid height")
+	}
+	height, err := strconv.Atoi(heightStr)
+	if err != nil {
+		return nil, errors.New("Invalid height")
+	}
 
-	data := make([][]uint8, height)
-	for i := range data {
-		data[i] = make([]uint8, width)
-		for j := range data[i] {
-			scanner.Scan()
-			val, _ := strconv.Atoi(scanner.Text())
-			data[i][j] = uint8(val)
+	maxValStr, err := hs.token()
+	if err != nil {
+		return nil, errors.New("Invalid max value")
+	}
+	maxVal, err := strconv.Atoi(maxValStr)
+	if err != nil {
+		return nil, errors.New("Invalid max value")
+	}
+
+	data := make([][]uint16, height)
+
+	if magicNumber == "P2" {
+		for i := range data {
+			data[i] = make([]uint16, width)
+			for j := range data[i] {
+				tok, err := hs.token()
+				if err != nil {
+					return nil, err
+				}
+				val, err := strconv.Atoi(tok)
+				if err != nil {
+					return nil, errors.New("Invalid pixel value")
+				}
+				data[i][j] = uint16(val)
+			}
+		}
+	} else if maxVal > 255 {
+		// 16-bit samples are stored big-endian, two bytes per sample.
+		raster := make([]byte, width*height*2)
+		if _, err := io.ReadFull(hs.r, raster); err != nil {
+			return nil, err
+		}
+		for i := range data {
+			data[i] = make([]uint16, width)
+			for j := 0; j < width; j++ {
+				offset := (i*width + j) * 2
+				data[i][j] = binary.BigEndian.Uint16(raster[offset : offset+2])
+			}
+		}
+	} else {
+		raster := make([]byte, width*height)
+		if _, err := io.ReadFull(hs.r, raster); err != nil {
+			return nil, err
+		}
+		for i := range data {
+			data[i] = make([]uint16, width)
+			for j := 0; j < width; j++ {
+				data[i][j] = uint16(raster[i*width+j])
+			}
 		}
 	}
 
@@ -65,29 +119,62 @@ func (pgm *PGM) Size() (int, int) {
 	return pgm.width, pgm.height
 }
 
-// At returns the value of the pixel at (x, y).
-func (pgm *PGM) At(x, y int) uint8 {
+// GrayAt returns the raw gray sample of the pixel at (x, y).
+func (pgm *PGM) GrayAt(x, y int) uint16 {
 	return pgm.data[y][x]
 }
 
-// Set sets the value of the pixel at (x, y).
-func (pgm *PGM) Set(x, y int, value uint8) {
+// SetGray sets the raw gray sample of the pixel at (x, y).
+func (pgm *PGM) SetGray(x, y int, value uint16) {
 	pgm.data[y][x] = value
 }
 
-// Save saves the PGM image to a file and returns an error if there was a problem.
-func (pgm *PGM) Save(filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+// ColorModel returns the Color Model of the image, satisfying image.Image.
+func (pgm *PGM) ColorModel() color.Model {
+	if pgm.max > 255 {
+		return color.Gray16Model
+	}
+	return color.GrayModel
+}
+
+// Bounds returns the domain for which At can return non-zero color,
+// satisfying image.Image.
+func (pgm *PGM) Bounds() image.Rectangle {
+	return image.Rect(0, 0, pgm.width, pgm.height)
+}
+
+// At returns the color of the pixel at (x, y), satisfying image.Image.
+func (pgm *PGM) At(x, y int) color.Color {
+	if pgm.max > 255 {
+		return color.Gray16{Y: pgm.data[y][x]}
 	}
-	defer file.Close()
+	return color.Gray{Y: uint8(pgm.data[y][x])}
+}
 
-	writer := bufio.NewWriter(file)
+// Save writes the PGM image to w and returns an error if there was a problem.
+func (pgm *PGM) Save(w io.Writer) error {
+	writer := bufio.NewWriter(w)
 	fmt.Fprintf(writer, "%s\n%d %d\n%d\n", pgm.magicNumber, pgm.width, pgm.height, pgm.max)
-	for i := 0; i < pgm.height; i++ {
-		for j := 0; j < pgm.width; j++ {
-			fmt.Fprintln(writer, pgm.data[i][j])
+
+	if pgm.magicNumber == "P2" {
+		for i := 0; i < pgm.height; i++ {
+			for j := 0; j < pgm.width; j++ {
+				fmt.Fprintln(writer, pgm.data[i][j])
+			}
+		}
+	} else if pgm.max > 255 {
+		sample := make([]byte, 2)
+		for i := 0; i < pgm.height; i++ {
+			for j := 0; j < pgm.width; j++ {
+				binary.BigEndian.PutUint16(sample, pgm.data[i][j])
+				writer.Write(sample)
+			}
+		}
+	} else {
+		for i := 0; i < pgm.height; i++ {
+			for j := 0; j < pgm.width; j++ {
+				writer.WriteByte(byte(pgm.data[i][j]))
+			}
 		}
 	}
 
@@ -96,23 +183,22 @@ func (pgm *PGM) Save(filename string) error {
 
 // Invert inverts the colors of the PGM image.
 func (pgm *PGM) Invert() {
-	for i := 0; i < pgm.height; i++ {
-		for j := 0; j < pgm.width; j++ {
-			pgm.data[i][j] = uint8(pgm.max) - pgm.data[i][j]
-		}
+	max := uint16(pgm.max)
+	for _, row := range pgm.data {
+		invertGrayRow(row, max)
 	}
 }
 
 // Flip flips the PGM image horizontally.
 func (pgm *PGM) Flip() {
-	for i := 0; i < pgm.height; i++ {
-		for j := 0; j < pgm.width/2; j++ {
-			pgm.data[i][j], pgm.data[i][pgm.width-j-1] = pgm.data[i][pgm.width-j-1], pgm.data[i][j]
-		}
+	for _, row := range pgm.data {
+		flipGrayRow(row)
 	}
 }
 
-// Flop flops the PGM image vertically.
+// Flop flops the PGM image vertically. Unlike Invert/Flip, this needs
+// random access across rows rather than a single row at a time, so it
+// isn't expressed as a row-wise primitive.
 func (pgm *PGM) Flop() {
 	for i := 0; i < pgm.height/2; i++ {
 		pgm.data[i], pgm.data[pgm.height-i-1] = pgm.data[pgm.height-i-1], pgm.data[i]
@@ -125,15 +211,15 @@ func (pgm *PGM) SetMagicNumber(magicNumber string) {
 }
 
 // SetMaxValue sets the max value of the PGM image.
-func (pgm *PGM) SetMaxValue(maxValue uint8) {
-	pgm.max = int(maxValue)
+func (pgm *PGM) SetMaxValue(maxValue int) {
+	pgm.max = maxValue
 }
 
 // Rotate90CW rotates the PGM image 90Â° clockwise.
 func (pgm *PGM) Rotate90CW() {
-	newData := make([][]uint8, pgm.width)
+	newData := make([][]uint16, pgm.width)
 	for i := range newData {
-		newData[i] = make([]uint8, pgm.height)
+		newData[i] = make([]uint16, pgm.height)
 		for j := range newData[i] {
 			newData[i][j] = pgm.data[pgm.height-j-1][i]
 		}
@@ -142,114 +228,141 @@ func (pgm *PGM) Rotate90CW() {
 	pgm.width, pgm.height = pgm.height, pgm.width
 }
 
-type PBM struct {
-	data          [][]bool
-	width, height int
-	magicNumber   string
-}
-
-func (pbm *PBM) Save(filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Write magic number, width, and height
-	_, err = fmt.Fprintf(file, "%s\n%d %d\n", pbm.magicNumber, pbm.width, pbm.height)
-	if err != nil {
-		return err
+// ToPBM converts the PGM image to PBM.
+func (pgm *PGM) ToPBM() *PBM {
+	max := uint16(pgm.max)
+	pbmData := make([][]bool, pgm.height)
+	for i, row := range pgm.data {
+		pbmData[i] = make([]bool, pgm.width)
+		thresholdGrayRow(row, max, pbmData[i])
 	}
 
-	// Write image data
-	for _, row := range pbm.data {
-		for _, pixel := range row {
-			if pbm.magicNumber == "P1" {
-				if pixel {
-					_, err = file.WriteString("1 ")
-				} else {
-					_, err = file.WriteString("0 ")
-				}
-			} else {
-				// For P4 format, write binary data
-				if pixel {
-					_, err = file.Write([]byte{0xFF})
-				} else {
-					_, err = file.Write([]byte{0x00})
-				}
-			}
-		}
-		_, err = file.WriteString("\n")
-		if err != nil {
-			return err
-		}
+	return &PBM{
+		data:        pbmData,
+		width:       pgm.width,
+		height:      pgm.height,
+		magicNumber: "P1", // Assuming "P1" is the magic number for PBM
 	}
-
-	return nil
 }
 
-// ToPBM converts the PGM image to PBM.
-func (pgm *PGM) ToPBM() *PBM {
-	pbmData := make([][]bool, pgm.height)
+// ToPPM converts the PGM image to PPM, duplicating the gray level into each
+// of the R, G and B channels. Samples wider than 8 bits are scaled down to
+// fit the PPM's uint8 channels.
+func (pgm *PGM) ToPPM() *PPM {
+	ppmData := make([][]Pixel, pgm.height)
 	for i := 0; i < pgm.height; i++ {
-		pbmData[i] = make([]bool, pgm.width)
+		ppmData[i] = make([]Pixel, pgm.width)
 		for j := 0; j < pgm.width; j++ {
-			pbmData[i][j] = pgm.data[i][j] > uint8(pgm.max)/2
+			gray := pgm.to8Bit(pgm.data[i][j])
+			ppmData[i][j] = Pixel{gray, gray, gray}
 		}
 	}
 
-	return &PBM{
-		data:        pbmData,
+	max := pgm.max
+	if max > 255 {
+		max = 255
+	}
+
+	return &PPM{
+		data:        ppmData,
 		width:       pgm.width,
 		height:      pgm.height,
-		magicNumber: "P1", // Assuming "P1" is the magic number for PBM
+		magicNumber: "P3",
+		max:         max,
 	}
 }
 
-func main() {
-	// Read a PGM image from a file
-	pgmFilename := "C:/Users/JENGO/Netbpm/paiement-passporr-timbre.pgm" // Change this to the actual path of your PGM file
-	pgm, err := ReadPGM(pgmFilename)
-	if err != nil {
-		fmt.Println("Error reading PGM:", err)
-		return
+// to8Bit scales a sample from this image's max value down to the 0-255
+// range, which is a no-op for already-8-bit PGMs.
+func (pgm *PGM) to8Bit(sample uint16) uint8 {
+	if pgm.max <= 255 {
+		return uint8(sample)
 	}
+	return uint8(uint32(sample) * 255 / uint32(pgm.max))
+}
 
-	// Display PGM information
-	fmt.Printf("PGM Magic Number: %s\n", pgm.magicNumber)
-	fmt.Printf("PGM Width: %d\n", pgm.width)
-	fmt.Printf("PGM Height: %d\n", pgm.height)
-	fmt.Printf("PGM Max Value: %d\n", pgm.max)
+// toPlane converts the image's samples into a float64 plane for use with
+// the shared resampling/convolution helpers.
+func (pgm *PGM) toPlane() [][]float64 {
+	plane := make([][]float64, pgm.height)
+	for y, row := range pgm.data {
+		plane[y] = make([]float64, pgm.width)
+		for x, v := range row {
+			plane[y][x] = float64(v)
+		}
+	}
+	return plane
+}
 
-	// Invert the colors of the PGM image
-	pgm.Invert()
+// fromPlane replaces the image's samples with a clamped, rounded plane
+// produced by the shared helpers, and updates width/height to match.
+func (pgm *PGM) fromPlane(plane [][]float64) {
+	height := len(plane)
+	width := 0
+	if height > 0 {
+		width = len(plane[0])
+	}
 
-	// Flip the PGM image horizontally
-	pgm.Flip()
+	data := make([][]uint16, height)
+	for y, row := range plane {
+		data[y] = make([]uint16, width)
+		for x, v := range row {
+			data[y][x] = clampSample(v, pgm.max)
+		}
+	}
 
-	// Save the modified PGM image
-	modifiedPGMFilename := "C:/Users/JENGO/Netbpm/pgmfile.pgm" // Change this to the desired output path
-	err = pgm.Save(modifiedPGMFilename)
-	if err != nil {
-		fmt.Println("Error saving modified PGM:", err)
-		return
+	pgm.data = data
+	pgm.width = width
+	pgm.height = height
+}
+
+func clampSample(v float64, max int) uint16 {
+	if v < 0 {
+		return 0
+	}
+	if v > float64(max) {
+		return uint16(max)
 	}
-	fmt.Println("Modified PGM image saved successfully to", modifiedPGMFilename)
+	return uint16(v + 0.5)
+}
 
-	// Convert the modified PGM image to PBM
-	pbm := pgm.ToPBM()
+// Resize scales the image to w x h using the given resampling filter.
+func (pgm *PGM) Resize(w, h int, filter ResampleFilter) {
+	pgm.fromPlane(resizePlane(pgm.toPlane(), w, h, filter))
+}
 
-	// Display PBM information
-	fmt.Printf("\nPBM Magic Number: %s\n", pbm.magicNumber)
-	fmt.Printf("PBM Width: %d\n", pbm.width)
-	fmt.Printf("PBM Height: %d\n", pbm.height)
+// Crop restricts the image to the portion of rect that overlaps its
+// bounds.
+func (pgm *PGM) Crop(rect image.Rectangle) {
+	rect = rect.Intersect(image.Rect(0, 0, pgm.width, pgm.height))
+	width, height := rect.Dx(), rect.Dy()
 
-	// Save the PBM image
-	pbmFilename := "C:/Users/JENGO/Netbpm/pgmfile.pgm" // Change this to the desired output path
-	err = pbm.Save(pbmFilename)
-	if err != nil {
-		fmt.Println("Error saving PBM:", err)
-		return
+	data := make([][]uint16, height)
+	for y := 0; y < height; y++ {
+		data[y] = make([]uint16, width)
+		copy(data[y], pgm.data[rect.Min.Y+y][rect.Min.X:rect.Min.X+width])
+	}
+
+	pgm.data = data
+	pgm.width = width
+	pgm.height = height
+}
+
+// RotateArbitrary rotates the image by degrees (clockwise, in degrees)
+// using a three-shear rotation, filling uncovered pixels with fill.
+func (pgm *PGM) RotateArbitrary(degrees float64, fill color.Color) {
+	gray := color.GrayModel.Convert(fill).(color.Gray)
+	fillVal := float64(gray.Y)
+	if pgm.max > 255 {
+		fillVal = float64(gray.Y) * float64(pgm.max) / 255
 	}
-	fmt.Println("PBM image saved successfully to", pbmFilename)
+
+	theta := degrees * math.Pi / 180
+	pgm.fromPlane(rotatePlaneThreeShear(pgm.toPlane(), theta, fillVal))
+}
+
+// GaussianBlur applies a two-pass separable Gaussian blur with the given
+// sigma to the image.
+func (pgm *PGM) GaussianBlur(sigma float64) {
+	pgm.fromPlane(blurPlane(pgm.toPlane(), sigma))
 }
@@ -0,0 +1,147 @@
+package netpbm
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestRowStreamingPBM round-trips a small P4 raster through
+// NewPBMRowWriter/NewPBMRowReader and checks the bytes come back unchanged.
+func TestRowStreamingPBM(t *testing.T) {
+	const width, height = 13, 3
+	rows := [][]byte{
+		{0xFF, 0xF8},
+		{0xAA, 0xA8},
+		{0x00, 0x00},
+	}
+
+	var buf bytes.Buffer
+	w := NewPBMRowWriter(&buf)
+	if err := w.WriteHeader(Header{Magic: "P4", Width: width, Height: height}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rr, err := NewPBMRowReader(&buf)
+	if err != nil {
+		t.Fatalf("NewPBMRowReader: %v", err)
+	}
+	if h := rr.Header(); h.Width != width || h.Height != height {
+		t.Fatalf("Header() = %+v, want width=%d height=%d", h, width, height)
+	}
+
+	got := make([]byte, 2)
+	for i, want := range rows {
+		if err := rr.ReadRow(got); err != nil {
+			t.Fatalf("ReadRow(%d): %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("row %d = %v, want %v", i, got, want)
+		}
+	}
+	if err := rr.ReadRow(got); err != io.EOF {
+		t.Fatalf("ReadRow after last row = %v, want io.EOF", err)
+	}
+}
+
+// TestRowStreamingPPM round-trips a small P6 raster through
+// NewPPMRowWriter/NewPPMRowReader and checks the bytes come back unchanged.
+func TestRowStreamingPPM(t *testing.T) {
+	const width, height = 2, 2
+	rows := [][]byte{
+		{255, 0, 0, 0, 255, 0},
+		{0, 0, 255, 255, 255, 255},
+	}
+
+	var buf bytes.Buffer
+	w := NewPPMRowWriter(&buf)
+	if err := w.WriteHeader(Header{Magic: "P6", Width: width, Height: height, Max: 255}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rr, err := NewPPMRowReader(&buf)
+	if err != nil {
+		t.Fatalf("NewPPMRowReader: %v", err)
+	}
+
+	got := make([]byte, width*3)
+	for i, want := range rows {
+		if err := rr.ReadRow(got); err != nil {
+			t.Fatalf("ReadRow(%d): %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("row %d = %v, want %v", i, got, want)
+		}
+	}
+	if err := rr.ReadRow(got); err != io.EOF {
+		t.Fatalf("ReadRow after last row = %v, want io.EOF", err)
+	}
+}
+
+// BenchmarkRowStreaming pipes a synthetic 100000x100000 P5 raster from a
+// RowWriter to a RowReader over an io.Pipe, reusing a single width-sized
+// buffer on each side. It demonstrates that streaming a raster this large
+// costs O(width) memory rather than the O(width*height) a fully
+// materialized PGM would need.
+func BenchmarkRowStreaming(b *testing.B) {
+	const width, height = 100000, 100000
+
+	row := make([]byte, width)
+	for i := range row {
+		row[i] = byte(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		pr, pw := io.Pipe()
+
+		go func() {
+			w := NewPGMRowWriter(pw)
+			if err := w.WriteHeader(Header{Magic: "P5", Width: width, Height: height, Max: 255}); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			for y := 0; y < height; y++ {
+				if err := w.WriteRow(row); err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+			}
+			pw.CloseWithError(w.Close())
+		}()
+
+		rr, err := NewPGMRowReader(pr)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		buf := make([]byte, width)
+		for {
+			if err := rr.ReadRow(buf); err != nil {
+				if err == io.EOF {
+					break
+				}
+				b.Fatal(err)
+			}
+		}
+		pr.Close()
+	}
+}